@@ -7,17 +7,38 @@ import (
 	"time"
 
 	"smarthome/models"
+	"smarthome/telemetry"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 // DB represents the database connection
 type DB struct {
-	Pool *pgxpool.Pool
+	Pool   *pgxpool.Pool
+	Events *EventBus
+}
+
+// querier is the subset of *pgxpool.Pool and pgx.Tx that DB's query helpers
+// need, so a helper like IngestReading can run standalone or inside a
+// caller's transaction.
+type querier interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// NewDB wraps a pgx pool, wiring up the event bus sensor writes publish to.
+func NewDB(pool *pgxpool.Pool) *DB {
+	return &DB{Pool: pool, Events: NewEventBus()}
 }
 
 // GetSensors returns all sensors
-func (db *DB) GetSensors(ctx context.Context) ([]models.Sensor, error) {
+func (db *DB) GetSensors(ctx context.Context) (_ []models.Sensor, err error) {
+	ctx, span := telemetry.Tracer.Start(ctx, "db.GetSensors")
+	defer func() { telemetry.EndSpan(span, err) }()
+
 	query := `
 		SELECT id, name, type, location, value, unit, status, last_updated, created_at
 		FROM sensors
@@ -54,7 +75,10 @@ func (db *DB) GetSensors(ctx context.Context) ([]models.Sensor, error) {
 }
 
 // GetSensorByID returns a sensor by its ID
-func (db *DB) GetSensorByID(ctx context.Context, id int) (models.Sensor, error) {
+func (db *DB) GetSensorByID(ctx context.Context, id int) (_ models.Sensor, err error) {
+	ctx, span := telemetry.Tracer.Start(ctx, "db.GetSensorByID")
+	defer func() { telemetry.EndSpan(span, err) }()
+
 	query := `
 		SELECT id, name, type, location, value, unit, status, last_updated, created_at
 		FROM sensors
@@ -62,7 +86,7 @@ func (db *DB) GetSensorByID(ctx context.Context, id int) (models.Sensor, error)
 	`
 
 	var s models.Sensor
-	err := db.Pool.QueryRow(ctx, query, id).Scan(
+	err = db.Pool.QueryRow(ctx, query, id).Scan(
 		&s.ID,
 		&s.Name,
 		&s.Type,
@@ -74,7 +98,8 @@ func (db *DB) GetSensorByID(ctx context.Context, id int) (models.Sensor, error)
 		&s.CreatedAt,
 	)
 	if err != nil {
-		return models.Sensor{}, fmt.Errorf("sensor not found")
+		err = fmt.Errorf("sensor not found")
+		return models.Sensor{}, err
 	}
 
 	return s, nil
@@ -82,6 +107,10 @@ func (db *DB) GetSensorByID(ctx context.Context, id int) (models.Sensor, error)
 
 // CreateSensor inserts a new sensor into the database
 func (db *DB) CreateSensor(ctx context.Context, sc models.SensorCreate) (models.Sensor, error) {
+	ctx, span := telemetry.Tracer.Start(ctx, "db.CreateSensor")
+	var err error
+	defer func() { telemetry.EndSpan(span, err) }()
+
 	query := `
 		INSERT INTO sensors (name, type, location, value, unit, status, last_updated, created_at)
 		VALUES ($1, $2, $3, $4, $5, 'inactive', $6, $7)
@@ -90,7 +119,7 @@ func (db *DB) CreateSensor(ctx context.Context, sc models.SensorCreate) (models.
 
 	now := time.Now().UTC()
 	var s models.Sensor
-	err := db.Pool.QueryRow(ctx, query,
+	err = db.Pool.QueryRow(ctx, query,
 		sc.Name,
 		sc.Type,
 		sc.Location,
@@ -118,6 +147,10 @@ func (db *DB) CreateSensor(ctx context.Context, sc models.SensorCreate) (models.
 
 // UpdateSensor updates a sensor's fields
 func (db *DB) UpdateSensor(ctx context.Context, id int, su models.SensorUpdate) (models.Sensor, error) {
+	ctx, span := telemetry.Tracer.Start(ctx, "db.UpdateSensor")
+	var err error
+	defer func() { telemetry.EndSpan(span, err) }()
+
 	current, err := db.GetSensorByID(ctx, id)
 	if err != nil {
 		return models.Sensor{}, err
@@ -177,28 +210,137 @@ func (db *DB) UpdateSensor(ctx context.Context, id int, su models.SensorUpdate)
 	return updated, nil
 }
 
-// UpdateSensorValue updates only the value and status of a sensor
-func (db *DB) UpdateSensorValue(ctx context.Context, id int, value float64, status string) error {
+// UpdateSensorValue updates a sensor's live value and status and appends the
+// reading to its history in a single transaction, so a failure on either
+// side leaves the sensor's value untouched and no event is published.
+func (db *DB) UpdateSensorValue(ctx context.Context, id int, value float64, status string) (err error) {
+	ctx, span := telemetry.Tracer.Start(ctx, "db.UpdateSensorValue")
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
 	query := `
 		UPDATE sensors
 		SET value = $1, status = $2, last_updated = $3
 		WHERE id = $4
+		RETURNING type, location, unit
 	`
 
-	result, err := db.Pool.Exec(ctx, query, value, status, time.Now().UTC(), id)
+	now := time.Now().UTC()
+	var sensorType models.SensorType
+	var location, unit string
+	err = tx.QueryRow(ctx, query, value, status, now, id).Scan(&sensorType, &location, &unit)
 	if err != nil {
-		return fmt.Errorf("error updating sensor value: %w", err)
+		return errors.New("sensor not found")
 	}
 
-	if result.RowsAffected() == 0 {
-		return errors.New("sensor not found")
+	if err = db.IngestReading(ctx, tx, id, value, status, now); err != nil {
+		return fmt.Errorf("error recording reading history: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing sensor value update: %w", err)
+	}
+
+	if db.Events != nil {
+		db.Events.Publish(Event{
+			SensorID:  id,
+			Type:      sensorType,
+			Location:  location,
+			Value:     value,
+			Unit:      unit,
+			Status:    status,
+			Timestamp: now,
+		})
 	}
 
 	return nil
 }
 
+// BatchReading is one sensor value update awaiting a batched write via
+// UpdateSensorValues.
+type BatchReading struct {
+	SensorID int
+	Value    float64
+	Status   string
+}
+
+// UpdateSensorValues updates each reading's sensor row and appends all of
+// them to history in a single transaction, batching the sensor_readings
+// insert via CopyFrom instead of one INSERT per reading — for high-rate
+// ingest paths (e.g. the MQTT bridge) where a transaction and round trip
+// per message can't keep up. A reading whose sensor doesn't exist is
+// skipped (returned in skipped) rather than aborting the rest of the batch.
+func (db *DB) UpdateSensorValues(ctx context.Context, readings []BatchReading) (skipped []int, err error) {
+	ctx, span := telemetry.Tracer.Start(ctx, "db.UpdateSensorValues")
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	if len(readings) == 0 {
+		return nil, nil
+	}
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		UPDATE sensors
+		SET value = $1, status = $2, last_updated = $3
+		WHERE id = $4
+		RETURNING type, location, unit
+	`
+
+	now := time.Now().UTC()
+	events := make([]Event, 0, len(readings))
+	rows := make([][]any, 0, len(readings))
+	for _, r := range readings {
+		var sensorType models.SensorType
+		var location, unit string
+		if err := tx.QueryRow(ctx, query, r.Value, r.Status, now, r.SensorID).Scan(&sensorType, &location, &unit); err != nil {
+			skipped = append(skipped, r.SensorID)
+			continue
+		}
+
+		events = append(events, Event{
+			SensorID:  r.SensorID,
+			Type:      sensorType,
+			Location:  location,
+			Value:     r.Value,
+			Unit:      unit,
+			Status:    r.Status,
+			Timestamp: now,
+		})
+		rows = append(rows, []any{r.SensorID, now, r.Value, r.Status})
+	}
+
+	if _, err = tx.CopyFrom(ctx, pgx.Identifier{"sensor_readings"}, []string{"sensor_id", "ts", "value", "status"}, pgx.CopyFromRows(rows)); err != nil {
+		return nil, fmt.Errorf("error batch-ingesting readings: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("error committing batch sensor update: %w", err)
+	}
+
+	if db.Events != nil {
+		for _, e := range events {
+			db.Events.Publish(e)
+		}
+	}
+
+	return skipped, nil
+}
+
 // DeleteSensor deletes a sensor by its ID
-func (db *DB) DeleteSensor(ctx context.Context, id int) error {
+func (db *DB) DeleteSensor(ctx context.Context, id int) (err error) {
+	ctx, span := telemetry.Tracer.Start(ctx, "db.DeleteSensor")
+	defer func() { telemetry.EndSpan(span, err) }()
+
 	query := `
 		DELETE FROM sensors
 		WHERE id = $1
@@ -0,0 +1,67 @@
+package db
+
+import (
+	"sync"
+	"time"
+
+	"smarthome/models"
+)
+
+// Event is published whenever a sensor's value is written, so subsystems
+// like the rule engine can react without being threaded through the write
+// path directly.
+type Event struct {
+	SensorID  int
+	Type      models.SensorType
+	Location  string
+	Value     float64
+	Unit      string
+	Status    string
+	Timestamp time.Time
+}
+
+// EventBus is a simple in-process pub/sub for sensor events. Subscribers get
+// a bounded channel; a slow subscriber has events dropped for it rather than
+// blocking publishers.
+type EventBus struct {
+	mu   sync.RWMutex
+	subs map[chan Event]struct{}
+}
+
+// NewEventBus creates an empty EventBus.
+func NewEventBus() *EventBus {
+	return &EventBus{subs: make(map[chan Event]struct{})}
+}
+
+// Subscribe returns a channel that receives future published events.
+// Unsubscribe by calling Unsubscribe with the same channel once done.
+func (b *EventBus) Subscribe(buffer int) chan Event {
+	ch := make(chan Event, buffer)
+	b.mu.Lock()
+	b.subs[ch] = struct{}{}
+	b.mu.Unlock()
+	return ch
+}
+
+// Unsubscribe removes and closes a channel previously returned by Subscribe.
+func (b *EventBus) Unsubscribe(ch chan Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subs[ch]; ok {
+		delete(b.subs, ch)
+		close(ch)
+	}
+}
+
+// Publish fans e out to every subscriber, dropping it for subscribers whose
+// channel is full instead of blocking.
+func (b *EventBus) Publish(e Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for ch := range b.subs {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"smarthome/telemetry"
+)
+
+// HistoryBucket is one downsampled point returned by GetSensorHistory.
+type HistoryBucket struct {
+	Bucket time.Time
+	Value  float64
+}
+
+// validHistoryAggs is the set of aggregation functions GetSensorHistory accepts.
+var validHistoryAggs = map[string]bool{
+	"avg":  true,
+	"min":  true,
+	"max":  true,
+	"last": true,
+}
+
+// IngestReading appends a single reading to sensor_readings at ts, via q so
+// it can run standalone (db.Pool) or as part of a caller's transaction
+// (e.g. UpdateSensorValue updating the sensor row and its history together).
+func (db *DB) IngestReading(ctx context.Context, q querier, sensorID int, value float64, status string, ts time.Time) (err error) {
+	ctx, span := telemetry.Tracer.Start(ctx, "db.IngestReading")
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	query := `
+		INSERT INTO sensor_readings (sensor_id, ts, value, status)
+		VALUES ($1, $2, $3, $4)
+	`
+
+	_, err = q.Exec(ctx, query, sensorID, ts, value, status)
+	if err != nil {
+		return fmt.Errorf("error ingesting reading: %w", err)
+	}
+
+	return nil
+}
+
+// AggregateSensorValue returns a single aggregate (agg: "avg", "min", "max",
+// or "last") over a sensor's readings in [from, to], with no further
+// time-bucketing. It's used for the rule engine's sliding-window functions
+// (avg_5m, etc.), where GetSensorHistory's fixed, epoch-aligned buckets
+// would cover only part of the requested window depending on when the rule
+// happens to fire.
+func (db *DB) AggregateSensorValue(ctx context.Context, sensorID int, from, to time.Time, agg string) (_ float64, err error) {
+	ctx, span := telemetry.Tracer.Start(ctx, "db.AggregateSensorValue")
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	if !validHistoryAggs[agg] {
+		return 0, fmt.Errorf("invalid aggregation %q", agg)
+	}
+
+	query := `
+		SELECT
+			CASE $1
+				WHEN 'avg' THEN avg(value)
+				WHEN 'min' THEN min(value)
+				WHEN 'max' THEN max(value)
+				ELSE (array_agg(value ORDER BY ts DESC))[1]
+			END AS value
+		FROM sensor_readings
+		WHERE sensor_id = $2 AND ts >= $3 AND ts <= $4
+	`
+
+	var value *float64
+	err = db.Pool.QueryRow(ctx, query, agg, sensorID, from, to).Scan(&value)
+	if err != nil {
+		return 0, fmt.Errorf("error aggregating sensor readings: %w", err)
+	}
+	if value == nil {
+		return 0, fmt.Errorf("no readings for sensor %d in range", sensorID)
+	}
+
+	return *value, nil
+}
+
+// GetSensorHistory returns downsampled history buckets for a sensor between
+// from and to, bucketed into step-wide windows and aggregated with agg
+// ("avg", "min", "max", or "last").
+func (db *DB) GetSensorHistory(ctx context.Context, sensorID int, from, to time.Time, step time.Duration, agg string) (_ []HistoryBucket, err error) {
+	ctx, span := telemetry.Tracer.Start(ctx, "db.GetSensorHistory")
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	if !validHistoryAggs[agg] {
+		return nil, fmt.Errorf("invalid aggregation %q", agg)
+	}
+	if step <= 0 {
+		return nil, fmt.Errorf("step must be positive")
+	}
+
+	stepSeconds := step.Seconds()
+
+	query := `
+		SELECT
+			to_timestamp(floor(extract(epoch from ts) / $1) * $1) AS bucket,
+			CASE $2
+				WHEN 'avg' THEN avg(value)
+				WHEN 'min' THEN min(value)
+				WHEN 'max' THEN max(value)
+				ELSE (array_agg(value ORDER BY ts DESC))[1]
+			END AS value
+		FROM sensor_readings
+		WHERE sensor_id = $3 AND ts >= $4 AND ts <= $5
+		GROUP BY bucket
+		ORDER BY bucket
+	`
+
+	rows, err := db.Pool.Query(ctx, query, stepSeconds, agg, sensorID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("error querying sensor history: %w", err)
+	}
+	defer rows.Close()
+
+	var buckets []HistoryBucket
+	for rows.Next() {
+		var b HistoryBucket
+		if err := rows.Scan(&b.Bucket, &b.Value); err != nil {
+			return nil, fmt.Errorf("error scanning history bucket: %w", err)
+		}
+		buckets = append(buckets, b)
+	}
+
+	return buckets, nil
+}
+
+// PruneReadingsOlderThan deletes readings for sensors of the given type that
+// are older than cutoff, used by the retention worker. sensorType may be
+// empty to prune across all sensor types.
+func (db *DB) PruneReadingsOlderThan(ctx context.Context, sensorType string, cutoff time.Time) (_ int64, err error) {
+	ctx, span := telemetry.Tracer.Start(ctx, "db.PruneReadingsOlderThan")
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	query := `
+		DELETE FROM sensor_readings
+		USING sensors
+		WHERE sensor_readings.sensor_id = sensors.id
+			AND sensor_readings.ts < $1
+			AND ($2 = '' OR sensors.type = $2)
+	`
+
+	result, err := db.Pool.Exec(ctx, query, cutoff, sensorType)
+	if err != nil {
+		return 0, fmt.Errorf("error pruning old readings: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// PruneReadingsOlderThanExcluding deletes readings older than cutoff for
+// sensor types other than those in excludeTypes, used by the retention
+// worker to apply RetentionPolicy.Default to every type not covered by its
+// own PerType entry.
+func (db *DB) PruneReadingsOlderThanExcluding(ctx context.Context, excludeTypes []string, cutoff time.Time) (_ int64, err error) {
+	ctx, span := telemetry.Tracer.Start(ctx, "db.PruneReadingsOlderThanExcluding")
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	query := `
+		DELETE FROM sensor_readings
+		USING sensors
+		WHERE sensor_readings.sensor_id = sensors.id
+			AND sensor_readings.ts < $1
+			AND NOT (sensors.type = ANY($2))
+	`
+
+	result, err := db.Pool.Exec(ctx, query, cutoff, excludeTypes)
+	if err != nil {
+		return 0, fmt.Errorf("error pruning old readings: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
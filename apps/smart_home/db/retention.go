@@ -0,0 +1,64 @@
+package db
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// RetentionPolicy configures how long sensor_readings are kept before being
+// pruned, with optional overrides per sensor type.
+type RetentionPolicy struct {
+	// Default is the retention window applied to sensor types with no
+	// entry in PerType.
+	Default time.Duration
+	// PerType overrides Default for specific sensor types (e.g. "temperature").
+	PerType map[string]time.Duration
+	// Interval controls how often the worker sweeps for expired readings.
+	Interval time.Duration
+}
+
+// RunRetentionWorker periodically prunes sensor_readings rows older than the
+// configured retention window, until ctx is cancelled. It's intended to be
+// started as a goroutine from main.go.
+func (db *DB) RunRetentionWorker(ctx context.Context, policy RetentionPolicy) {
+	interval := policy.Interval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			db.pruneOnce(ctx, policy)
+		}
+	}
+}
+
+// pruneOnce sweeps each PerType override at its own window, then applies
+// Default to every sensor type not covered by PerType.
+func (db *DB) pruneOnce(ctx context.Context, policy RetentionPolicy) {
+	overridden := make([]string, 0, len(policy.PerType))
+	for sensorType, window := range policy.PerType {
+		overridden = append(overridden, sensorType)
+
+		cutoff := time.Now().UTC().Add(-window)
+		if n, err := db.PruneReadingsOlderThan(ctx, sensorType, cutoff); err != nil {
+			log.Printf("retention worker: failed to prune %s readings: %v", sensorType, err)
+		} else if n > 0 {
+			log.Printf("retention worker: pruned %d %s readings older than %s", n, sensorType, cutoff)
+		}
+	}
+
+	cutoff := time.Now().UTC().Add(-policy.Default)
+	if n, err := db.PruneReadingsOlderThanExcluding(ctx, overridden, cutoff); err != nil {
+		log.Printf("retention worker: failed to prune readings: %v", err)
+	} else if n > 0 {
+		log.Printf("retention worker: pruned %d readings older than %s (default window)", n, cutoff)
+	}
+}
@@ -0,0 +1,271 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"smarthome/models"
+	"smarthome/telemetry"
+)
+
+// Rule is an automation trigger evaluated whenever a sensor value is
+// written: when the event's sensor matches the rule's selector and When
+// evaluates true for it, Then fires, subject to Cooldown.
+//
+// The selector narrows which sensors a rule applies to: SensorID matches a
+// single sensor exactly; Type and Location match by sensor attribute and
+// may be combined (e.g. Type "temperature" + Location "kitchen"). A zero
+// value for any of the three means "don't filter on this field". SensorID
+// takes precedence when set.
+type Rule struct {
+	ID              int
+	Name            string
+	SensorID        *int
+	Type            models.SensorType
+	Location        string
+	When            string
+	ThenType        string // "webhook", "mqtt", or "log"
+	ThenTarget      string // webhook URL or MQTT topic; unused for "log"
+	Enabled         bool
+	CooldownSeconds int
+	LastFiredAt     *time.Time
+	CreatedAt       time.Time
+}
+
+// Matches reports whether e's sensor satisfies r's selector. A rule with no
+// selector fields set matches every sensor.
+func (r Rule) Matches(e Event) bool {
+	if r.SensorID != nil {
+		return e.SensorID == *r.SensorID
+	}
+	if r.Type != "" && e.Type != r.Type {
+		return false
+	}
+	if r.Location != "" && e.Location != r.Location {
+		return false
+	}
+	return true
+}
+
+// RuleCreate is the payload accepted by POST /api/v1/rules.
+type RuleCreate struct {
+	Name            string            `json:"name" binding:"required"`
+	SensorID        *int              `json:"sensor_id"`
+	Type            models.SensorType `json:"type"`
+	Location        string            `json:"location"`
+	When            string            `json:"when" binding:"required"`
+	ThenType        string            `json:"then_type" binding:"required"`
+	ThenTarget      string            `json:"then_target"`
+	CooldownSeconds int               `json:"cooldown_seconds"`
+}
+
+// RuleUpdate is the payload accepted by PUT /api/v1/rules/:id; zero-value
+// fields are left unchanged, matching SensorUpdate's convention.
+type RuleUpdate struct {
+	Name            string            `json:"name"`
+	SensorID        *int              `json:"sensor_id"`
+	Type            models.SensorType `json:"type"`
+	Location        string            `json:"location"`
+	When            string            `json:"when"`
+	ThenType        string            `json:"then_type"`
+	ThenTarget      string            `json:"then_target"`
+	Enabled         *bool             `json:"enabled"`
+	CooldownSeconds *int              `json:"cooldown_seconds"`
+}
+
+// GetRules returns all rules.
+func (db *DB) GetRules(ctx context.Context) (_ []Rule, err error) {
+	ctx, span := telemetry.Tracer.Start(ctx, "db.GetRules")
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	query := `
+		SELECT id, name, sensor_id, type, location, "when", then_type, then_target, enabled, cooldown_seconds, last_fired_at, created_at
+		FROM rules
+		ORDER BY id
+	`
+
+	rows, err := db.Pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("error querying rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []Rule
+	for rows.Next() {
+		var r Rule
+		if err := rows.Scan(&r.ID, &r.Name, &r.SensorID, &r.Type, &r.Location, &r.When, &r.ThenType, &r.ThenTarget, &r.Enabled, &r.CooldownSeconds, &r.LastFiredAt, &r.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning rule row: %w", err)
+		}
+		rules = append(rules, r)
+	}
+
+	return rules, nil
+}
+
+// GetEnabledRules returns all rules with enabled = true, used by the rule
+// engine on every sensor event.
+func (db *DB) GetEnabledRules(ctx context.Context) (_ []Rule, err error) {
+	ctx, span := telemetry.Tracer.Start(ctx, "db.GetEnabledRules")
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	query := `
+		SELECT id, name, sensor_id, type, location, "when", then_type, then_target, enabled, cooldown_seconds, last_fired_at, created_at
+		FROM rules
+		WHERE enabled
+		ORDER BY id
+	`
+
+	rows, err := db.Pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("error querying enabled rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []Rule
+	for rows.Next() {
+		var r Rule
+		if err := rows.Scan(&r.ID, &r.Name, &r.SensorID, &r.Type, &r.Location, &r.When, &r.ThenType, &r.ThenTarget, &r.Enabled, &r.CooldownSeconds, &r.LastFiredAt, &r.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning rule row: %w", err)
+		}
+		rules = append(rules, r)
+	}
+
+	return rules, nil
+}
+
+// GetRuleByID returns a rule by its ID.
+func (db *DB) GetRuleByID(ctx context.Context, id int) (_ Rule, err error) {
+	ctx, span := telemetry.Tracer.Start(ctx, "db.GetRuleByID")
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	query := `
+		SELECT id, name, sensor_id, type, location, "when", then_type, then_target, enabled, cooldown_seconds, last_fired_at, created_at
+		FROM rules
+		WHERE id = $1
+	`
+
+	var r Rule
+	err = db.Pool.QueryRow(ctx, query, id).Scan(
+		&r.ID, &r.Name, &r.SensorID, &r.Type, &r.Location, &r.When, &r.ThenType, &r.ThenTarget, &r.Enabled, &r.CooldownSeconds, &r.LastFiredAt, &r.CreatedAt,
+	)
+	if err != nil {
+		err = fmt.Errorf("rule not found")
+		return Rule{}, err
+	}
+
+	return r, nil
+}
+
+// CreateRule inserts a new rule, enabled by default.
+func (db *DB) CreateRule(ctx context.Context, rc RuleCreate) (_ Rule, err error) {
+	ctx, span := telemetry.Tracer.Start(ctx, "db.CreateRule")
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	query := `
+		INSERT INTO rules (name, sensor_id, type, location, "when", then_type, then_target, enabled, cooldown_seconds, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, true, $8, $9)
+		RETURNING id, name, sensor_id, type, location, "when", then_type, then_target, enabled, cooldown_seconds, last_fired_at, created_at
+	`
+
+	var r Rule
+	err = db.Pool.QueryRow(ctx, query,
+		rc.Name, rc.SensorID, rc.Type, rc.Location, rc.When, rc.ThenType, rc.ThenTarget, rc.CooldownSeconds, time.Now().UTC(),
+	).Scan(&r.ID, &r.Name, &r.SensorID, &r.Type, &r.Location, &r.When, &r.ThenType, &r.ThenTarget, &r.Enabled, &r.CooldownSeconds, &r.LastFiredAt, &r.CreatedAt)
+	if err != nil {
+		return Rule{}, fmt.Errorf("error creating rule: %w", err)
+	}
+
+	return r, nil
+}
+
+// UpdateRule updates a rule's fields, leaving zero-value fields in ru unchanged.
+func (db *DB) UpdateRule(ctx context.Context, id int, ru RuleUpdate) (_ Rule, err error) {
+	ctx, span := telemetry.Tracer.Start(ctx, "db.UpdateRule")
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	current, err := db.GetRuleByID(ctx, id)
+	if err != nil {
+		return Rule{}, err
+	}
+
+	if ru.Name != "" {
+		current.Name = ru.Name
+	}
+	if ru.SensorID != nil {
+		current.SensorID = ru.SensorID
+	}
+	if ru.Type != "" {
+		current.Type = ru.Type
+	}
+	if ru.Location != "" {
+		current.Location = ru.Location
+	}
+	if ru.When != "" {
+		current.When = ru.When
+	}
+	if ru.ThenType != "" {
+		current.ThenType = ru.ThenType
+	}
+	if ru.ThenTarget != "" {
+		current.ThenTarget = ru.ThenTarget
+	}
+	if ru.Enabled != nil {
+		current.Enabled = *ru.Enabled
+	}
+	if ru.CooldownSeconds != nil {
+		current.CooldownSeconds = *ru.CooldownSeconds
+	}
+
+	query := `
+		UPDATE rules
+		SET name = $1, sensor_id = $2, type = $3, location = $4, "when" = $5, then_type = $6, then_target = $7, enabled = $8, cooldown_seconds = $9
+		WHERE id = $10
+		RETURNING id, name, sensor_id, type, location, "when", then_type, then_target, enabled, cooldown_seconds, last_fired_at, created_at
+	`
+
+	var updated Rule
+	err = db.Pool.QueryRow(ctx, query,
+		current.Name, current.SensorID, current.Type, current.Location, current.When, current.ThenType, current.ThenTarget, current.Enabled, current.CooldownSeconds, id,
+	).Scan(&updated.ID, &updated.Name, &updated.SensorID, &updated.Type, &updated.Location, &updated.When, &updated.ThenType, &updated.ThenTarget, &updated.Enabled, &updated.CooldownSeconds, &updated.LastFiredAt, &updated.CreatedAt)
+	if err != nil {
+		return Rule{}, fmt.Errorf("error updating rule: %w", err)
+	}
+
+	return updated, nil
+}
+
+// DeleteRule deletes a rule by its ID.
+func (db *DB) DeleteRule(ctx context.Context, id int) (err error) {
+	ctx, span := telemetry.Tracer.Start(ctx, "db.DeleteRule")
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	query := `DELETE FROM rules WHERE id = $1`
+
+	result, err := db.Pool.Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("error deleting rule: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("rule not found")
+	}
+
+	return nil
+}
+
+// MarkRuleFired records that a rule most recently fired at t. Per-sensor
+// cooldown enforcement lives in the rule engine's in-memory state, not
+// here; this column is kept for display purposes only.
+func (db *DB) MarkRuleFired(ctx context.Context, id int, t time.Time) (err error) {
+	ctx, span := telemetry.Tracer.Start(ctx, "db.MarkRuleFired")
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	query := `UPDATE rules SET last_fired_at = $1 WHERE id = $2`
+
+	_, err = db.Pool.Exec(ctx, query, t, id)
+	if err != nil {
+		return fmt.Errorf("error marking rule fired: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetSensorHistory handles GET /api/v1/sensors/:id/history
+//
+// Query params:
+//   - from, to: RFC3339 timestamps bounding the range (default: last hour)
+//   - step:     bucket width, as a Go duration string (default: "5m")
+//   - agg:      aggregation applied per bucket: avg|min|max|last (default: avg)
+func (h *SensorHandler) GetSensorHistory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid sensor ID"})
+		return
+	}
+
+	to := time.Now().UTC()
+	if v := c.Query("to"); v != "" {
+		to, err = time.Parse(time.RFC3339, v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to timestamp"})
+			return
+		}
+	}
+
+	// from defaults to an hour before to (not before now), so a from-less
+	// request with an explicit to still gets "the last hour ending at to".
+	from := to.Add(-time.Hour)
+	if v := c.Query("from"); v != "" {
+		from, err = time.Parse(time.RFC3339, v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from timestamp"})
+			return
+		}
+	}
+
+	step := 5 * time.Minute
+	if v := c.Query("step"); v != "" {
+		step, err = time.ParseDuration(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid step"})
+			return
+		}
+	}
+
+	agg := c.DefaultQuery("agg", "avg")
+
+	buckets, err := h.DB.GetSensorHistory(c.Request.Context(), id, from, to, step, agg)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, buckets)
+}
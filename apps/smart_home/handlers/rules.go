@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"smarthome/db"
+	"smarthome/rules"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RuleHandler handles automation rule CRUD requests
+type RuleHandler struct {
+	DB *db.DB
+}
+
+// NewRuleHandler creates a new RuleHandler
+func NewRuleHandler(db *db.DB) *RuleHandler {
+	return &RuleHandler{DB: db}
+}
+
+// RegisterRoutes registers rule routes
+func (h *RuleHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rules := rg.Group("/rules")
+	{
+		rules.GET("", h.GetRules)
+		rules.GET("/:id", h.GetRuleByID)
+		rules.POST("", h.CreateRule)
+		rules.PUT("/:id", h.UpdateRule)
+		rules.DELETE("/:id", h.DeleteRule)
+	}
+}
+
+// GetRules handles GET /api/v1/rules
+func (h *RuleHandler) GetRules(c *gin.Context) {
+	rules, err := h.DB.GetRules(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+// GetRuleByID handles GET /api/v1/rules/:id
+func (h *RuleHandler) GetRuleByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid rule ID"})
+		return
+	}
+
+	rule, err := h.DB.GetRuleByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "rule not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// CreateRule handles POST /api/v1/rules
+func (h *RuleHandler) CreateRule(c *gin.Context) {
+	var req db.RuleCreate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err := rules.Parse(req.When); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid when expression: %v", err)})
+		return
+	}
+
+	rule, err := h.DB.CreateRule(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+// UpdateRule handles PUT /api/v1/rules/:id
+func (h *RuleHandler) UpdateRule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid rule ID"})
+		return
+	}
+
+	var req db.RuleUpdate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.When != "" {
+		if _, err := rules.Parse(req.When); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid when expression: %v", err)})
+			return
+		}
+	}
+
+	updated, err := h.DB.UpdateRule(c.Request.Context(), id, req)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("rule %d not found", id)})
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// DeleteRule handles DELETE /api/v1/rules/:id
+func (h *RuleHandler) DeleteRule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid rule ID"})
+		return
+	}
+
+	if err := h.DB.DeleteRule(c.Request.Context(), id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
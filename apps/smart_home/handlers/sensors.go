@@ -6,25 +6,40 @@ import (
 	"log"
 	"net/http"
 	"strconv"
+	"time"
 
 	"smarthome/db"
 	"smarthome/models"
 	"smarthome/services"
 
 	"github.com/gin-gonic/gin"
+	"golang.org/x/sync/errgroup"
 )
 
+// driverFetchTimeout bounds each per-sensor upstream fetch so one slow
+// sensor can't eat the whole request's budget.
+const driverFetchTimeout = 2 * time.Second
+
 // SensorHandler handles sensor-related requests
 type SensorHandler struct {
 	DB                 *db.DB
 	TemperatureService *services.TemperatureService
+	Drivers            *services.DriverRegistry
+
+	// ShutdownCtx is canceled when the server begins graceful shutdown, so
+	// long-lived stream/ws handlers can stop promptly instead of blocking
+	// srv.Shutdown until their client disconnects.
+	ShutdownCtx context.Context
 }
 
-// NewSensorHandler creates a new SensorHandler
-func NewSensorHandler(db *db.DB, temperatureService *services.TemperatureService) *SensorHandler {
+// NewSensorHandler creates a new SensorHandler. shutdownCtx is canceled when
+// the server begins graceful shutdown.
+func NewSensorHandler(db *db.DB, temperatureService *services.TemperatureService, drivers *services.DriverRegistry, shutdownCtx context.Context) *SensorHandler {
 	return &SensorHandler{
 		DB:                 db,
 		TemperatureService: temperatureService,
+		Drivers:            drivers,
+		ShutdownCtx:        shutdownCtx,
 	}
 }
 
@@ -33,7 +48,10 @@ func (h *SensorHandler) RegisterRoutes(rg *gin.RouterGroup) {
 	sensors := rg.Group("/sensors")
 	{
 		sensors.GET("", h.GetSensors)
+		sensors.GET("/stream", h.GetSensorsStream)
+		sensors.GET("/ws", h.GetSensorsWS)
 		sensors.GET("/:id", h.GetSensorByID)
+		sensors.GET("/:id/history", h.GetSensorHistory)
 		sensors.POST("", h.CreateSensor)
 		sensors.PUT("/:id", h.UpdateSensor)
 		sensors.PATCH("/:id/value", h.UpdateSensorValue)
@@ -43,25 +61,33 @@ func (h *SensorHandler) RegisterRoutes(rg *gin.RouterGroup) {
 
 // GetSensors handles GET /api/v1/sensors
 func (h *SensorHandler) GetSensors(c *gin.Context) {
-	sensors, err := h.DB.GetSensors(context.Background())
+	sensors, err := h.DB.GetSensors(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Enrich temperature sensors with random values from temperature-api
+	// Enrich sensors with live values from their driver, fetched concurrently
+	// so one slow sensor doesn't stall the whole listing.
+	g, gctx := errgroup.WithContext(c.Request.Context())
 	for i := range sensors {
-		if sensors[i].Type == models.Temperature {
-			temp, err := h.TemperatureService.GetTemperature(sensors[i].Location)
+		i := i
+		g.Go(func() error {
+			ctx, cancel := context.WithTimeout(gctx, driverFetchTimeout)
+			defer cancel()
+
+			reading, err := h.Drivers.Fetch(ctx, sensors[i])
 			if err != nil {
-				log.Printf("failed to get temperature for %s: %v", sensors[i].Location, err)
-				continue
+				log.Printf("failed to fetch reading for sensor %d (%s): %v", sensors[i].ID, sensors[i].Type, err)
+				return nil
 			}
-			sensors[i].Value = temp.Value
-			sensors[i].Unit = temp.Unit
-			sensors[i].LastUpdated = temp.Timestamp
-		}
+			sensors[i].Value = reading.Value
+			sensors[i].Unit = reading.Unit
+			sensors[i].LastUpdated = reading.Timestamp
+			return nil
+		})
 	}
+	_ = g.Wait()
 
 	c.JSON(http.StatusOK, sensors)
 }
@@ -74,19 +100,18 @@ func (h *SensorHandler) GetSensorByID(c *gin.Context) {
 		return
 	}
 
-	sensor, err := h.DB.GetSensorByID(context.Background(), id)
+	sensor, err := h.DB.GetSensorByID(c.Request.Context(), id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "sensor not found"})
 		return
 	}
 
-	if sensor.Type == models.Temperature {
-		temp, err := h.TemperatureService.GetTemperature(sensor.Location)
-		if err == nil && temp != nil {
-			sensor.Value = temp.Value
-			sensor.Unit = temp.Unit
-			sensor.LastUpdated = temp.Timestamp
-		}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), driverFetchTimeout)
+	defer cancel()
+	if reading, err := h.Drivers.Fetch(ctx, sensor); err == nil {
+		sensor.Value = reading.Value
+		sensor.Unit = reading.Unit
+		sensor.LastUpdated = reading.Timestamp
 	}
 
 	c.JSON(http.StatusOK, sensor)
@@ -100,7 +125,7 @@ func (h *SensorHandler) CreateSensor(c *gin.Context) {
 		return
 	}
 
-	sensor, err := h.DB.CreateSensor(context.Background(), req)
+	sensor, err := h.DB.CreateSensor(c.Request.Context(), req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -123,7 +148,7 @@ func (h *SensorHandler) UpdateSensor(c *gin.Context) {
 		return
 	}
 
-	updated, err := h.DB.UpdateSensor(context.Background(), id, req)
+	updated, err := h.DB.UpdateSensor(c.Request.Context(), id, req)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("sensor %d not found", id)})
 		return
@@ -154,7 +179,7 @@ func (h *SensorHandler) UpdateSensorValue(c *gin.Context) {
 		status = "active"
 	}
 
-	err = h.DB.UpdateSensorValue(context.Background(), id, request.Value, status)
+	err = h.DB.UpdateSensorValue(c.Request.Context(), id, request.Value, status)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -171,7 +196,7 @@ func (h *SensorHandler) DeleteSensor(c *gin.Context) {
 		return
 	}
 
-	if err := h.DB.DeleteSensor(context.Background(), id); err != nil {
+	if err := h.DB.DeleteSensor(c.Request.Context(), id); err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"smarthome/db"
+	"smarthome/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// streamBufferSize bounds each subscriber's event channel; once full,
+	// the EventBus drops events for that subscriber rather than blocking
+	// the sensor write path.
+	streamBufferSize = 32
+
+	streamHeartbeatPeriod = 15 * time.Second
+)
+
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	// The stream carries no secrets and is consumed by dashboards and
+	// internal tooling from a variety of hosts.
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// streamEvent is the payload shape pushed to stream subscribers over both
+// SSE and WebSocket.
+type streamEvent struct {
+	SensorID  int               `json:"sensor_id"`
+	Type      models.SensorType `json:"type,omitempty"`
+	Value     float64           `json:"value"`
+	Unit      string            `json:"unit,omitempty"`
+	Status    string            `json:"status"`
+	Timestamp time.Time         `json:"ts"`
+}
+
+func toStreamEvent(e db.Event) streamEvent {
+	return streamEvent{
+		SensorID:  e.SensorID,
+		Type:      e.Type,
+		Value:     e.Value,
+		Unit:      e.Unit,
+		Status:    e.Status,
+		Timestamp: e.Timestamp,
+	}
+}
+
+// streamFilter narrows the event bus to what a client asked for via the
+// optional ?sensor_id= and ?type= query params.
+type streamFilter struct {
+	sensorID  int
+	hasSensor bool
+	typ       models.SensorType
+	hasType   bool
+}
+
+func parseStreamFilter(c *gin.Context) (streamFilter, error) {
+	var f streamFilter
+	if v := c.Query("sensor_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			return streamFilter{}, err
+		}
+		f.sensorID, f.hasSensor = id, true
+	}
+	if v := c.Query("type"); v != "" {
+		f.typ, f.hasType = models.SensorType(v), true
+	}
+	return f, nil
+}
+
+func (f streamFilter) matches(e db.Event) bool {
+	if f.hasSensor && e.SensorID != f.sensorID {
+		return false
+	}
+	if f.hasType && e.Type != f.typ {
+		return false
+	}
+	return true
+}
+
+// GetSensorsStream handles GET /api/v1/sensors/stream, pushing a
+// server-sent event to the client every time a sensor value is updated.
+// Optional ?sensor_id= and ?type= query params narrow the stream to
+// matching events; a periodic heartbeat keeps idle connections alive.
+func (h *SensorHandler) GetSensorsStream(c *gin.Context) {
+	filter, err := parseStreamFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid sensor_id"})
+		return
+	}
+
+	events := h.DB.Events.Subscribe(streamBufferSize)
+	defer h.DB.Events.Unsubscribe(events)
+
+	heartbeat := time.NewTicker(streamHeartbeatPeriod)
+	defer heartbeat.Stop()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-h.ShutdownCtx.Done():
+			return false
+		case <-c.Request.Context().Done():
+			return false
+		case <-heartbeat.C:
+			c.SSEvent("ping", "")
+			return true
+		case ev, ok := <-events:
+			if !ok {
+				return false
+			}
+			if filter.matches(ev) {
+				c.SSEvent("sensor", toStreamEvent(ev))
+			}
+			return true
+		}
+	})
+}
+
+// GetSensorsWS handles GET /api/v1/sensors/ws, the WebSocket equivalent of
+// GetSensorsStream: one JSON message per matching sensor update, plus
+// periodic ping frames.
+func (h *SensorHandler) GetSensorsWS(c *gin.Context) {
+	filter, err := parseStreamFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid sensor_id"})
+		return
+	}
+
+	conn, err := wsUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Printf("sensor stream: websocket upgrade failed: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	events := h.DB.Events.Subscribe(streamBufferSize)
+	defer h.DB.Events.Unsubscribe(events)
+
+	heartbeat := time.NewTicker(streamHeartbeatPeriod)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-h.ShutdownCtx.Done():
+			return
+		case <-c.Request.Context().Done():
+			return
+		case <-heartbeat.C:
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+			if !filter.matches(ev) {
+				continue
+			}
+			if err := conn.WriteJSON(toStreamEvent(ev)); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package rules
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"smarthome/db"
+)
+
+// MQTTPublisher is the subset of the MQTT bridge's client that action
+// dispatch needs; satisfied directly by *mqtt.Bridge.
+type MQTTPublisher interface {
+	Publish(topic string, payload []byte) error
+}
+
+// Dispatcher fires a rule's Then action.
+type Dispatcher struct {
+	HTTPClient *http.Client
+	MQTT       MQTTPublisher // nil if no MQTT bridge is configured
+}
+
+// NewDispatcher creates a Dispatcher. MQTT may be nil.
+func NewDispatcher(mqttPublisher MQTTPublisher) *Dispatcher {
+	return &Dispatcher{
+		HTTPClient: &http.Client{Timeout: 5 * time.Second},
+		MQTT:       mqttPublisher,
+	}
+}
+
+type actionPayload struct {
+	Rule      string    `json:"rule"`
+	SensorID  int       `json:"sensor_id"`
+	Value     float64   `json:"value"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Dispatch fires rule's configured action for the triggering event.
+func (d *Dispatcher) Dispatch(ctx context.Context, rule db.Rule, event db.Event) error {
+	payload := actionPayload{Rule: rule.Name, SensorID: event.SensorID, Value: event.Value, Timestamp: event.Timestamp}
+
+	switch rule.ThenType {
+	case "log":
+		log.Printf("rule %q fired for sensor %d (value=%v)", rule.Name, event.SensorID, event.Value)
+		return nil
+	case "webhook":
+		return d.dispatchWebhook(ctx, rule.ThenTarget, payload)
+	case "mqtt":
+		return d.dispatchMQTT(rule.ThenTarget, payload)
+	default:
+		return fmt.Errorf("unknown action type %q", rule.ThenType)
+	}
+}
+
+func (d *Dispatcher) dispatchWebhook(ctx context.Context, url string, payload actionPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("error encoding webhook payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error building webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := d.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error calling webhook %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s returned status %d", url, resp.StatusCode)
+	}
+	return nil
+}
+
+func (d *Dispatcher) dispatchMQTT(topic string, payload actionPayload) error {
+	if d.MQTT == nil {
+		return fmt.Errorf("mqtt action configured but no MQTT bridge is running")
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("error encoding mqtt payload: %w", err)
+	}
+
+	return d.MQTT.Publish(topic, body)
+}
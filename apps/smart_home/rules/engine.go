@@ -0,0 +1,117 @@
+package rules
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"smarthome/db"
+)
+
+// Engine evaluates enabled rules against sensor events as they're published,
+// dispatching each rule's action when the event's sensor matches the rule's
+// selector, its When expression matches, and its cooldown has elapsed. It
+// runs in its own goroutine, consuming events off db.DB's event bus so the
+// sensor write path stays fast.
+type Engine struct {
+	DB         *db.DB
+	Dispatcher *Dispatcher
+
+	mu        sync.Mutex
+	lastFired map[ruleSensorKey]time.Time
+}
+
+// ruleSensorKey scopes cooldowns to a single (rule, sensor) pair, so one
+// rule matching many sensors doesn't have its cooldown tripped globally by
+// whichever sensor happens to fire it first.
+type ruleSensorKey struct {
+	ruleID   int
+	sensorID int
+}
+
+// NewEngine creates an Engine.
+func NewEngine(database *db.DB, dispatcher *Dispatcher) *Engine {
+	return &Engine{DB: database, Dispatcher: dispatcher, lastFired: make(map[ruleSensorKey]time.Time)}
+}
+
+// Run subscribes to db.Events and evaluates rules until ctx is cancelled.
+func (e *Engine) Run(ctx context.Context) {
+	events := e.DB.Events.Subscribe(64)
+	defer e.DB.Events.Unsubscribe(events)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev := <-events:
+			e.handleEvent(ctx, ev)
+		}
+	}
+}
+
+func (e *Engine) handleEvent(ctx context.Context, ev db.Event) {
+	rules, err := e.DB.GetEnabledRules(ctx)
+	if err != nil {
+		log.Printf("rules: failed to load enabled rules: %v", err)
+		return
+	}
+
+	for _, rule := range rules {
+		if !rule.Matches(ev) {
+			continue
+		}
+		if e.onCooldown(rule, ev.SensorID) {
+			continue
+		}
+
+		n, err := Parse(rule.When)
+		if err != nil {
+			log.Printf("rules: rule %q has an invalid expression: %v", rule.Name, err)
+			continue
+		}
+
+		matched, err := Eval(n, EvalContext{Context: ctx, DB: e.DB, SensorID: ev.SensorID, Value: ev.Value})
+		if err != nil {
+			// Most commonly "no history yet" for a freshly-added sensor;
+			// not worth logging on every event.
+			continue
+		}
+		if !matched {
+			continue
+		}
+
+		if err := e.Dispatcher.Dispatch(ctx, rule, ev); err != nil {
+			log.Printf("rules: rule %q action failed: %v", rule.Name, err)
+		}
+
+		firedAt := time.Now().UTC()
+		e.markFired(rule.ID, ev.SensorID, firedAt)
+		if err := e.DB.MarkRuleFired(ctx, rule.ID, firedAt); err != nil {
+			log.Printf("rules: failed to record rule %q firing: %v", rule.Name, err)
+		}
+	}
+}
+
+// onCooldown reports whether rule is still on cooldown for sensorID,
+// tracked per (rule, sensor) so a rule matching many sensors doesn't block
+// on one sensor's cooldown for the rest.
+func (e *Engine) onCooldown(rule db.Rule, sensorID int) bool {
+	if rule.CooldownSeconds <= 0 {
+		return false
+	}
+
+	e.mu.Lock()
+	last, fired := e.lastFired[ruleSensorKey{ruleID: rule.ID, sensorID: sensorID}]
+	e.mu.Unlock()
+	if !fired {
+		return false
+	}
+	return time.Since(last) < time.Duration(rule.CooldownSeconds)*time.Second
+}
+
+func (e *Engine) markFired(ruleID, sensorID int, at time.Time) {
+	e.mu.Lock()
+	e.lastFired[ruleSensorKey{ruleID: ruleID, sensorID: sensorID}] = at
+	e.mu.Unlock()
+}
@@ -0,0 +1,293 @@
+// Package rules evaluates automation rules against sensor events and fires
+// their configured action, with per-rule cooldowns to avoid flapping.
+package rules
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"smarthome/db"
+)
+
+// EvalContext is the data a rule's When expression is evaluated against.
+type EvalContext struct {
+	Context  context.Context
+	DB       *db.DB
+	SensorID int
+	Value    float64
+}
+
+var tokenPattern = regexp.MustCompile(`\s*(>=|<=|==|!=|[><()]|,|[A-Za-z_][A-Za-z0-9_.]*|[0-9]+(?:\.[0-9]+)?)`)
+
+// tokenize splits a When expression into tokens, skipping whitespace.
+func tokenize(expr string) ([]string, error) {
+	expr = strings.TrimSpace(expr)
+	var tokens []string
+	pos := 0
+	for pos < len(expr) {
+		loc := tokenPattern.FindStringSubmatchIndex(expr[pos:])
+		if loc == nil || loc[0] != 0 {
+			return nil, fmt.Errorf("unexpected character at %q", expr[pos:])
+		}
+		tokens = append(tokens, expr[pos+loc[2]:pos+loc[3]])
+		pos += loc[1]
+	}
+	return tokens, nil
+}
+
+// node is anything that can be evaluated to a float64 (literals, sensor.value,
+// and window aggregate calls) or a bool (comparisons, AND/OR).
+type node interface{}
+
+type numberLit float64
+
+type identifier string
+
+type funcCall struct {
+	name string
+	args []node
+}
+
+type comparison struct {
+	op          string
+	left, right node
+}
+
+type boolOp struct {
+	op          string // "AND" or "OR"
+	left, right node
+}
+
+// parser is a small recursive-descent parser for:
+//
+//	expr       := andExpr (OR andExpr)*
+//	andExpr    := comparison (AND comparison)*
+//	comparison := operand comparator operand
+//	operand    := number | identifier | funcCall
+//	funcCall   := identifier '(' operand (',' operand)* ')'
+type parser struct {
+	tokens []string
+	pos    int
+}
+
+// Parse compiles a When expression into an evaluatable node.
+func Parse(expr string) (node, error) {
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	n, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.tokens) {
+		return nil, fmt.Errorf("unexpected trailing token %q", p.tokens[p.pos])
+	}
+	return n, nil
+}
+
+func (p *parser) peek() (string, bool) {
+	if p.pos >= len(p.tokens) {
+		return "", false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok, ok := p.peek()
+		if !ok || !strings.EqualFold(tok, "OR") {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = boolOp{op: "OR", left: left, right: right}
+	}
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseComparison()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok, ok := p.peek()
+		if !ok || !strings.EqualFold(tok, "AND") {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseComparison()
+		if err != nil {
+			return nil, err
+		}
+		left = boolOp{op: "AND", left: left, right: right}
+	}
+}
+
+var comparators = map[string]bool{">": true, "<": true, ">=": true, "<=": true, "==": true, "!=": true}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	op, ok := p.peek()
+	if !ok || !comparators[op] {
+		return nil, fmt.Errorf("expected comparator, got %q", op)
+	}
+	p.pos++
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	return comparison{op: op, left: left, right: right}, nil
+}
+
+func (p *parser) parseOperand() (node, error) {
+	tok, ok := p.peek()
+	if !ok {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+
+	if v, err := strconv.ParseFloat(tok, 64); err == nil {
+		p.pos++
+		return numberLit(v), nil
+	}
+
+	p.pos++
+	next, hasNext := p.peek()
+	if hasNext && next == "(" {
+		p.pos++
+		var args []node
+		for {
+			arg, err := p.parseOperand()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			sep, ok := p.peek()
+			if !ok {
+				return nil, fmt.Errorf("unterminated function call %q", tok)
+			}
+			if sep == "," {
+				p.pos++
+				continue
+			}
+			if sep == ")" {
+				p.pos++
+				break
+			}
+			return nil, fmt.Errorf("expected ',' or ')' in call to %q, got %q", tok, sep)
+		}
+		return funcCall{name: tok, args: args}, nil
+	}
+
+	return identifier(tok), nil
+}
+
+// windowFuncPattern matches names like avg_5m, max_1h, last_30s.
+var windowFuncPattern = regexp.MustCompile(`^(avg|min|max|last)_(\d+)(s|m|h)$`)
+
+// Eval evaluates a boolean node (top-level comparison/AND/OR) against ctx.
+func Eval(n node, ctx EvalContext) (bool, error) {
+	switch v := n.(type) {
+	case boolOp:
+		left, err := Eval(v.left, ctx)
+		if err != nil {
+			return false, err
+		}
+		right, err := Eval(v.right, ctx)
+		if err != nil {
+			return false, err
+		}
+		if v.op == "AND" {
+			return left && right, nil
+		}
+		return left || right, nil
+	case comparison:
+		left, err := evalNumber(v.left, ctx)
+		if err != nil {
+			return false, err
+		}
+		right, err := evalNumber(v.right, ctx)
+		if err != nil {
+			return false, err
+		}
+		switch v.op {
+		case ">":
+			return left > right, nil
+		case "<":
+			return left < right, nil
+		case ">=":
+			return left >= right, nil
+		case "<=":
+			return left <= right, nil
+		case "==":
+			return left == right, nil
+		case "!=":
+			return left != right, nil
+		}
+		return false, fmt.Errorf("unknown comparator %q", v.op)
+	default:
+		return false, fmt.Errorf("expected a boolean expression")
+	}
+}
+
+// evalNumber evaluates a numeric node: a literal, sensor.value, or a sliding
+// window aggregate like avg_5m(sensor.value).
+func evalNumber(n node, ctx EvalContext) (float64, error) {
+	switch v := n.(type) {
+	case numberLit:
+		return float64(v), nil
+	case identifier:
+		if v == "sensor.value" {
+			return ctx.Value, nil
+		}
+		return 0, fmt.Errorf("unknown identifier %q", v)
+	case funcCall:
+		match := windowFuncPattern.FindStringSubmatch(v.name)
+		if match == nil {
+			return 0, fmt.Errorf("unknown function %q", v.name)
+		}
+		if len(v.args) != 1 {
+			return 0, fmt.Errorf("%s expects exactly one argument", v.name)
+		}
+		if arg, ok := v.args[0].(identifier); !ok || arg != "sensor.value" {
+			return 0, fmt.Errorf("%s only supports sensor.value", v.name)
+		}
+
+		agg := match[1]
+		n, _ := strconv.Atoi(match[2])
+		var window time.Duration
+		switch match[3] {
+		case "s":
+			window = time.Duration(n) * time.Second
+		case "m":
+			window = time.Duration(n) * time.Minute
+		case "h":
+			window = time.Duration(n) * time.Hour
+		}
+
+		to := time.Now().UTC()
+		from := to.Add(-window)
+		value, err := ctx.DB.AggregateSensorValue(ctx.Context, ctx.SensorID, from, to, agg)
+		if err != nil {
+			return 0, fmt.Errorf("error evaluating %s: %w", v.name, err)
+		}
+		return value, nil
+	default:
+		return 0, fmt.Errorf("expected a numeric expression")
+	}
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned by CircuitBreaker.Allow when calls are
+// currently being rejected.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// breakerState is the state machine used by CircuitBreaker.
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// CircuitBreaker is a minimal closed/open/half-open breaker keyed by a
+// failure-rate threshold over a rolling window of recent calls, with a
+// cooldown before probing the upstream again.
+type CircuitBreaker struct {
+	// FailureThreshold is the failure rate (0..1) over the trailing window
+	// that trips the breaker from closed to open.
+	FailureThreshold float64
+	// MinRequests is the minimum number of calls in the window before the
+	// failure rate is evaluated, avoiding tripping on a tiny sample.
+	MinRequests int
+	// WindowSize bounds how many recent outcomes are considered.
+	WindowSize int
+	// Cooldown is how long the breaker stays open before allowing a single
+	// half-open probe.
+	Cooldown time.Duration
+
+	mu            sync.Mutex
+	state         breakerState
+	outcomes      []bool // true = success
+	openedAt      time.Time
+	probeInFlight bool
+}
+
+// NewCircuitBreaker builds a CircuitBreaker with the given thresholds.
+func NewCircuitBreaker(failureThreshold float64, minRequests, windowSize int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		FailureThreshold: failureThreshold,
+		MinRequests:      minRequests,
+		WindowSize:       windowSize,
+		Cooldown:         cooldown,
+	}
+}
+
+// Allow reports whether a call should proceed. When the breaker is open and
+// the cooldown has elapsed, it allows exactly one half-open probe through.
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if time.Since(b.openedAt) < b.Cooldown {
+			return false
+		}
+		if b.probeInFlight {
+			return false
+		}
+		b.state = breakerHalfOpen
+		b.probeInFlight = true
+		return true
+	case breakerHalfOpen:
+		return false
+	default:
+		return true
+	}
+}
+
+// RecordSuccess reports a successful call, closing the breaker if it was
+// half-open.
+func (b *CircuitBreaker) RecordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == breakerHalfOpen {
+		b.reset()
+		return
+	}
+
+	b.record(true)
+}
+
+// RecordFailure reports a failed call, re-opening the breaker immediately if
+// it was half-open, or tripping it if the closed-state failure rate reaches
+// FailureThreshold.
+func (b *CircuitBreaker) RecordFailure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == breakerHalfOpen {
+		b.trip()
+		return
+	}
+
+	b.record(false)
+
+	if len(b.outcomes) >= b.MinRequests && b.failureRate() >= b.FailureThreshold {
+		b.trip()
+	}
+}
+
+// State returns a human-readable state name, for /metrics.
+func (b *CircuitBreaker) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		return "open"
+	case breakerHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+func (b *CircuitBreaker) record(success bool) {
+	b.outcomes = append(b.outcomes, success)
+	if len(b.outcomes) > b.WindowSize {
+		b.outcomes = b.outcomes[len(b.outcomes)-b.WindowSize:]
+	}
+}
+
+func (b *CircuitBreaker) failureRate() float64 {
+	if len(b.outcomes) == 0 {
+		return 0
+	}
+	failures := 0
+	for _, ok := range b.outcomes {
+		if !ok {
+			failures++
+		}
+	}
+	return float64(failures) / float64(len(b.outcomes))
+}
+
+func (b *CircuitBreaker) trip() {
+	b.state = breakerOpen
+	b.openedAt = time.Now()
+	b.probeInFlight = false
+	b.outcomes = nil
+}
+
+func (b *CircuitBreaker) reset() {
+	b.state = breakerClosed
+	b.probeInFlight = false
+	b.outcomes = nil
+}
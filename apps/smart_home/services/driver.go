@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"smarthome/models"
+)
+
+// Reading is a sensor value fetched from an upstream source by a SensorDriver.
+type Reading struct {
+	Value     float64
+	Unit      string
+	Timestamp time.Time
+}
+
+// SensorDriver fetches a live reading for a sensor of a particular kind. It
+// lets GetSensors enrich any sensor type without special-casing it in the
+// handler: the handler just looks up the driver registered for sensor.Type.
+type SensorDriver interface {
+	Kind() models.SensorType
+	Fetch(ctx context.Context, sensor models.Sensor) (Reading, error)
+}
+
+// DriverRegistry maps a sensor type to the driver that knows how to fetch
+// live readings for it. Sensors whose type has no registered driver but
+// that carry a DriverConfig fall back to the generic HTTP driver, so adding
+// a new sensor category doesn't require a code change.
+type DriverRegistry struct {
+	mu      sync.RWMutex
+	drivers map[models.SensorType]SensorDriver
+	generic *GenericHTTPDriver
+}
+
+// NewDriverRegistry returns an empty registry. Register drivers with Register.
+func NewDriverRegistry() *DriverRegistry {
+	return &DriverRegistry{
+		drivers: make(map[models.SensorType]SensorDriver),
+		generic: NewGenericHTTPDriver(),
+	}
+}
+
+// Register adds a driver, keyed by its Kind(). Registering a second driver
+// for the same kind replaces the first.
+func (r *DriverRegistry) Register(d SensorDriver) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.drivers[d.Kind()] = d
+}
+
+// Driver returns the driver registered for kind, if any.
+func (r *DriverRegistry) Driver(kind models.SensorType) (SensorDriver, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	d, ok := r.drivers[kind]
+	return d, ok
+}
+
+// Fetch looks up the driver for sensor.Type and fetches a reading. If no
+// driver is registered for that type but the sensor carries a DriverConfig,
+// it falls back to the generic HTTP driver.
+func (r *DriverRegistry) Fetch(ctx context.Context, sensor models.Sensor) (Reading, error) {
+	if d, ok := r.Driver(sensor.Type); ok {
+		return d.Fetch(ctx, sensor)
+	}
+	if len(sensor.DriverConfig) > 0 {
+		return r.generic.Fetch(ctx, sensor)
+	}
+	return Reading{}, fmt.Errorf("no driver registered for sensor type %q", sensor.Type)
+}
@@ -0,0 +1,287 @@
+// Package mqtt bridges an MQTT broker's sensor topics into the same write
+// path HTTP clients use, so physical devices can push readings directly
+// instead of being polled over HTTP.
+package mqtt
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"smarthome/db"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+)
+
+// PayloadFormat selects how an incoming message body is decoded into a
+// sensor value.
+type PayloadFormat string
+
+const (
+	// PayloadJSON expects a body like {"value": 21.5, "status": "active"}.
+	PayloadJSON PayloadFormat = "json"
+	// PayloadPlainNumeric expects the body to be a bare numeric string, e.g. "21.5".
+	PayloadPlainNumeric PayloadFormat = "plain"
+)
+
+// Config configures the MQTT ingestion bridge.
+type Config struct {
+	// BrokerURL is a tcp:// or ssl:// broker address, e.g. "ssl://broker:8883".
+	BrokerURL string
+	ClientID  string
+	Username  string
+	Password  string
+	// TLSConfig enables TLS, supplying client certs when the broker requires them.
+	TLSConfig *tls.Config
+
+	// TopicFilter is the subscription filter, e.g. "warmhouse/sensors/+/value".
+	TopicFilter string
+	// SensorIDPattern extracts the sensor ID from a topic via a named
+	// capture group "id", e.g. `^warmhouse/sensors/(?P<id>\d+)/value$`.
+	SensorIDPattern *regexp.Regexp
+	// PayloadFormat selects the decoder used for message bodies.
+	PayloadFormat PayloadFormat
+
+	// QoS is the subscription QoS level (bridge is built around QoS 1).
+	QoS byte
+	// Workers bounds how many goroutines process decoded readings concurrently.
+	Workers int
+	// BatchSize bounds how many readings each worker groups into a single
+	// batched write via db.DB.UpdateSensorValues.
+	BatchSize int
+	// BatchInterval bounds how long a worker waits to fill BatchSize before
+	// flushing a partial batch, so readings don't stall during low traffic.
+	BatchInterval time.Duration
+	// WriteTimeout bounds each batched DB write.
+	WriteTimeout time.Duration
+
+	// MaxReconnectInterval caps the backoff between reconnect attempts.
+	MaxReconnectInterval time.Duration
+}
+
+// jsonPayload is the shape accepted by PayloadJSON.
+type jsonPayload struct {
+	Value  float64 `json:"value"`
+	Status string  `json:"status"`
+}
+
+// reading is a decoded message awaiting a DB write.
+type reading struct {
+	sensorID int
+	value    float64
+	status   string
+}
+
+// Bridge subscribes to MQTT sensor topics and writes decoded readings
+// through db.DB.UpdateSensorValues in batches, so ingest throughput isn't
+// bounded by one transaction and INSERT per reading.
+type Bridge struct {
+	cfg    Config
+	db     *db.DB
+	client paho.Client
+	queue  chan reading
+	done   chan struct{}
+}
+
+// NewBridge creates a Bridge. Call Start to connect and begin consuming.
+func NewBridge(cfg Config, database *db.DB) (*Bridge, error) {
+	if cfg.TopicFilter == "" {
+		return nil, fmt.Errorf("mqtt: topic filter is required")
+	}
+	if cfg.SensorIDPattern == nil {
+		return nil, fmt.Errorf("mqtt: sensor ID pattern is required")
+	}
+	if cfg.QoS == 0 {
+		cfg.QoS = 1
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = 4
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 50
+	}
+	if cfg.BatchInterval <= 0 {
+		cfg.BatchInterval = 500 * time.Millisecond
+	}
+	if cfg.WriteTimeout <= 0 {
+		cfg.WriteTimeout = 3 * time.Second
+	}
+	if cfg.MaxReconnectInterval <= 0 {
+		cfg.MaxReconnectInterval = time.Minute
+	}
+	if cfg.PayloadFormat == "" {
+		cfg.PayloadFormat = PayloadJSON
+	}
+
+	b := &Bridge{
+		cfg:   cfg,
+		db:    database,
+		queue: make(chan reading, 256),
+		done:  make(chan struct{}),
+	}
+
+	opts := paho.NewClientOptions().
+		AddBroker(cfg.BrokerURL).
+		SetClientID(cfg.ClientID).
+		SetUsername(cfg.Username).
+		SetPassword(cfg.Password).
+		SetTLSConfig(cfg.TLSConfig).
+		SetAutoReconnect(true).
+		SetMaxReconnectInterval(cfg.MaxReconnectInterval).
+		SetConnectionLostHandler(func(_ paho.Client, err error) {
+			log.Printf("mqtt: connection lost: %v", err)
+		}).
+		SetOnConnectHandler(func(c paho.Client) {
+			if token := c.Subscribe(cfg.TopicFilter, cfg.QoS, b.onMessage); token.Wait() && token.Error() != nil {
+				log.Printf("mqtt: failed to subscribe to %s: %v", cfg.TopicFilter, token.Error())
+			}
+		})
+
+	b.client = paho.NewClient(opts)
+	return b, nil
+}
+
+// Start connects to the broker and launches the worker pool. It returns once
+// the initial connection attempt completes; reconnects happen in the background.
+func (b *Bridge) Start(ctx context.Context) error {
+	for i := 0; i < b.cfg.Workers; i++ {
+		go b.worker(ctx)
+	}
+
+	if token := b.client.Connect(); token.Wait() && token.Error() != nil {
+		return fmt.Errorf("mqtt: failed to connect to %s: %w", b.cfg.BrokerURL, token.Error())
+	}
+
+	go func() {
+		<-ctx.Done()
+		b.Stop()
+	}()
+
+	return nil
+}
+
+// Stop disconnects from the broker and stops the worker pool.
+func (b *Bridge) Stop() {
+	select {
+	case <-b.done:
+		return
+	default:
+		close(b.done)
+	}
+	b.client.Disconnect(250)
+}
+
+// Publish sends a message to the broker, e.g. for rule actions that target
+// an actuator topic. It implements rules.MQTTPublisher.
+func (b *Bridge) Publish(topic string, payload []byte) error {
+	token := b.client.Publish(topic, b.cfg.QoS, false, payload)
+	token.Wait()
+	return token.Error()
+}
+
+// onMessage is the paho message handler; it decodes the payload and enqueues
+// it for a worker, dropping the message if the queue is full so a slow DB
+// never blocks the MQTT client loop.
+func (b *Bridge) onMessage(_ paho.Client, msg paho.Message) {
+	sensorID, err := b.sensorIDFromTopic(msg.Topic())
+	if err != nil {
+		log.Printf("mqtt: %v", err)
+		return
+	}
+
+	value, status, err := b.decodePayload(msg.Payload())
+	if err != nil {
+		log.Printf("mqtt: failed to decode payload on %s: %v", msg.Topic(), err)
+		return
+	}
+
+	select {
+	case b.queue <- reading{sensorID: sensorID, value: value, status: status}:
+	default:
+		log.Printf("mqtt: write queue full, dropping reading for sensor %d", sensorID)
+	}
+}
+
+func (b *Bridge) sensorIDFromTopic(topic string) (int, error) {
+	match := b.cfg.SensorIDPattern.FindStringSubmatch(topic)
+	if match == nil {
+		return 0, fmt.Errorf("topic %q does not match sensor ID pattern", topic)
+	}
+	idx := b.cfg.SensorIDPattern.SubexpIndex("id")
+	if idx < 0 || idx >= len(match) {
+		return 0, fmt.Errorf("sensor ID pattern has no \"id\" capture group")
+	}
+	return strconv.Atoi(match[idx])
+}
+
+func (b *Bridge) decodePayload(payload []byte) (value float64, status string, err error) {
+	switch b.cfg.PayloadFormat {
+	case PayloadPlainNumeric:
+		value, err = strconv.ParseFloat(strings.TrimSpace(string(payload)), 64)
+		if err != nil {
+			return 0, "", fmt.Errorf("invalid numeric payload: %w", err)
+		}
+		return value, "active", nil
+	default:
+		var p jsonPayload
+		if err := json.Unmarshal(payload, &p); err != nil {
+			return 0, "", fmt.Errorf("invalid JSON payload: %w", err)
+		}
+		if p.Status == "" {
+			p.Status = "active"
+		}
+		return p.Value, p.Status, nil
+	}
+}
+
+// worker groups decoded readings off the queue into batches of up to
+// BatchSize, flushing early on BatchInterval so a quiet period doesn't
+// leave readings waiting, and writes each batch in a single call to
+// db.DB.UpdateSensorValues.
+func (b *Bridge) worker(ctx context.Context) {
+	batch := make([]db.BatchReading, 0, b.cfg.BatchSize)
+	ticker := time.NewTicker(b.cfg.BatchInterval)
+	defer ticker.Stop()
+
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+
+		writeCtx, cancel := context.WithTimeout(ctx, b.cfg.WriteTimeout)
+		skipped, err := b.db.UpdateSensorValues(writeCtx, batch)
+		cancel()
+		if err != nil {
+			log.Printf("mqtt: failed to write batch of %d readings: %v", len(batch), err)
+		}
+		for _, sensorID := range skipped {
+			log.Printf("mqtt: failed to write reading for sensor %d: sensor not found", sensorID)
+		}
+
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			flush()
+			return
+		case <-b.done:
+			flush()
+			return
+		case r := <-b.queue:
+			batch = append(batch, db.BatchReading{SensorID: r.sensorID, Value: r.value, Status: r.status})
+			if len(batch) >= b.cfg.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
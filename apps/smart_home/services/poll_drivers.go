@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"smarthome/models"
+)
+
+// pollResponse is the common response shape expected from the humidity,
+// motion and power upstream APIs: {"value": ..., "unit": ..., "timestamp": ...}.
+type pollResponse struct {
+	Value     float64   `json:"value"`
+	Unit      string    `json:"unit"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// httpPollDriver is a SensorDriver that GETs "<baseURL><path>?location=" and
+// decodes a pollResponse, the same shape TemperatureService's upstream uses.
+// It backs the humidity, motion and power/energy built-in drivers.
+type httpPollDriver struct {
+	kind       models.SensorType
+	baseURL    string
+	path       string
+	httpClient *http.Client
+}
+
+func newHTTPPollDriver(kind models.SensorType, baseURL, path string) *httpPollDriver {
+	return &httpPollDriver{
+		kind:       kind,
+		baseURL:    baseURL,
+		path:       path,
+		httpClient: &http.Client{Timeout: 3 * time.Second},
+	}
+}
+
+// NewHumidityDriver fetches humidity readings from a humidity-api reachable at baseURL.
+func NewHumidityDriver(baseURL string) SensorDriver {
+	return newHTTPPollDriver(models.Humidity, baseURL, "/humidity")
+}
+
+// NewMotionDriver fetches motion readings from a motion-api reachable at baseURL.
+func NewMotionDriver(baseURL string) SensorDriver {
+	return newHTTPPollDriver(models.Motion, baseURL, "/motion")
+}
+
+// NewPowerDriver fetches power/energy readings from a power-api reachable at baseURL.
+func NewPowerDriver(baseURL string) SensorDriver {
+	return newHTTPPollDriver(models.Power, baseURL, "/power")
+}
+
+func (d *httpPollDriver) Kind() models.SensorType { return d.kind }
+
+func (d *httpPollDriver) Fetch(ctx context.Context, sensor models.Sensor) (Reading, error) {
+	u, err := url.Parse(d.baseURL + d.path)
+	if err != nil {
+		return Reading{}, fmt.Errorf("error parsing base URL: %w", err)
+	}
+
+	q := u.Query()
+	q.Set("location", sensor.Location)
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return Reading{}, fmt.Errorf("error building %s request: %w", d.kind, err)
+	}
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return Reading{}, fmt.Errorf("error calling %s api: %w", d.kind, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Reading{}, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var pr pollResponse
+	if err := json.NewDecoder(resp.Body).Decode(&pr); err != nil {
+		return Reading{}, fmt.Errorf("error decoding %s response: %w", d.kind, err)
+	}
+
+	return Reading{Value: pr.Value, Unit: pr.Unit, Timestamp: pr.Timestamp}, nil
+}
@@ -0,0 +1,36 @@
+package services
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	temperatureCacheSizeDesc = prometheus.NewDesc(
+		"smarthome_temperature_cache_size",
+		"Number of locations currently cached by TemperatureService.",
+		nil, nil,
+	)
+	temperatureBreakerOpenDesc = prometheus.NewDesc(
+		"smarthome_temperature_breaker_open",
+		"1 if TemperatureService's circuit breaker is open or half-open, 0 if closed.",
+		nil, nil,
+	)
+)
+
+// Describe implements prometheus.Collector.
+func (s *TemperatureService) Describe(ch chan<- *prometheus.Desc) {
+	ch <- temperatureCacheSizeDesc
+	ch <- temperatureBreakerOpenDesc
+}
+
+// Collect implements prometheus.Collector, exposing cache size and breaker
+// state on every scrape.
+func (s *TemperatureService) Collect(ch chan<- prometheus.Metric) {
+	stats := s.Stats()
+
+	ch <- prometheus.MustNewConstMetric(temperatureCacheSizeDesc, prometheus.GaugeValue, float64(stats.CachedLocations))
+
+	open := 0.0
+	if stats.BreakerState != "closed" {
+		open = 1
+	}
+	ch <- prometheus.MustNewConstMetric(temperatureBreakerOpenDesc, prometheus.GaugeValue, open)
+}
@@ -1,17 +1,45 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
+	"sync"
 	"time"
+
+	"smarthome/models"
+	"smarthome/telemetry"
+
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/sync/singleflight"
 )
 
-// TemperatureService handles fetching temperature data from external API
+// TemperatureService handles fetching temperature data from external API. It
+// keeps a per-location cache in front of the upstream call, de-duplicates
+// concurrent fetches for the same location, and fails fast via a circuit
+// breaker when the upstream is unhealthy so a slow/down temperature-api
+// can't stall every request that touches it.
 type TemperatureService struct {
 	BaseURL    string
 	HTTPClient *http.Client
+
+	cacheTTL time.Duration
+	breaker  *CircuitBreaker
+	group    singleflight.Group
+
+	mu    sync.RWMutex
+	cache map[string]cacheEntry
+
+	refreshInterval time.Duration
+	stop            chan struct{}
+	stopOnce        sync.Once
+}
+
+type cacheEntry struct {
+	resp      TemperatureResponse
+	fetchedAt time.Time
 }
 
 // TemperatureResponse represents the response from the temperature API
@@ -22,18 +50,126 @@ type TemperatureResponse struct {
 	Location  string    `json:"location"`
 }
 
+// Stats summarizes cache and breaker health for the /metrics endpoint.
+type Stats struct {
+	CachedLocations int    `json:"cached_locations"`
+	BreakerState    string `json:"breaker_state"`
+}
+
+// Option configures a TemperatureService.
+type Option func(*TemperatureService)
+
+// WithCacheTTL overrides the default cache entry lifetime.
+func WithCacheTTL(ttl time.Duration) Option {
+	return func(s *TemperatureService) { s.cacheTTL = ttl }
+}
+
+// WithBreaker overrides the default circuit breaker thresholds.
+func WithBreaker(b *CircuitBreaker) Option {
+	return func(s *TemperatureService) { s.breaker = b }
+}
+
+// WithBackgroundRefresh enables a goroutine that re-fetches cached locations
+// on a fixed interval, keeping hot locations warm. A zero interval disables
+// background refresh (the default).
+func WithBackgroundRefresh(interval time.Duration) Option {
+	return func(s *TemperatureService) { s.refreshInterval = interval }
+}
+
 // NewTemperatureService creates a new TemperatureService
-func NewTemperatureService(baseURL string) *TemperatureService {
-	return &TemperatureService{
+func NewTemperatureService(baseURL string, opts ...Option) *TemperatureService {
+	s := &TemperatureService{
 		BaseURL: baseURL,
 		HTTPClient: &http.Client{
 			Timeout: 3 * time.Second,
 		},
+		cacheTTL: 30 * time.Second,
+		breaker:  NewCircuitBreaker(0.5, 5, 20, 15*time.Second),
+		cache:    make(map[string]cacheEntry),
+		stop:     make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.refreshInterval > 0 {
+		go s.runBackgroundRefresh()
+	}
+
+	return s
+}
+
+// Close stops the background refresher, if one was started.
+func (s *TemperatureService) Close() {
+	s.stopOnce.Do(func() { close(s.stop) })
+}
+
+// Stats reports current cache size and breaker state, for the /metrics endpoint.
+func (s *TemperatureService) Stats() Stats {
+	s.mu.RLock()
+	n := len(s.cache)
+	s.mu.RUnlock()
+
+	return Stats{
+		CachedLocations: n,
+		BreakerState:    s.breaker.State(),
+	}
+}
+
+// GetTemperature fetches the temperature for a given location, serving a
+// cached value when fresh, de-duplicating concurrent fetches for the same
+// location, and failing fast via the circuit breaker when the upstream is
+// unhealthy.
+func (s *TemperatureService) GetTemperature(ctx context.Context, location string) (*TemperatureResponse, error) {
+	if cached, ok := s.cached(location); ok {
+		return cached, nil
 	}
+
+	if !s.breaker.Allow() {
+		return nil, ErrCircuitOpen
+	}
+
+	v, err, _ := s.group.Do(location, func() (interface{}, error) {
+		resp, err := s.fetch(ctx, location)
+		if err != nil {
+			s.breaker.RecordFailure()
+			return nil, err
+		}
+		s.breaker.RecordSuccess()
+		s.store(location, *resp)
+		return resp, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v.(*TemperatureResponse), nil
 }
 
-// GetTemperature fetches the temperature for a given location
-func (s *TemperatureService) GetTemperature(location string) (*TemperatureResponse, error) {
+func (s *TemperatureService) cached(location string) (*TemperatureResponse, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entry, ok := s.cache[location]
+	if !ok || time.Since(entry.fetchedAt) >= s.cacheTTL {
+		return nil, false
+	}
+	resp := entry.resp
+	return &resp, true
+}
+
+func (s *TemperatureService) store(location string, resp TemperatureResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cache[location] = cacheEntry{resp: resp, fetchedAt: time.Now()}
+}
+
+func (s *TemperatureService) fetch(ctx context.Context, location string) (_ *TemperatureResponse, err error) {
+	ctx, span := telemetry.Tracer.Start(ctx, "temperature_service.fetch")
+	span.SetAttributes(attribute.String("location", location))
+	defer func() { telemetry.EndSpan(span, err) }()
+
 	u, err := url.Parse(s.BaseURL + "/temperature")
 	if err != nil {
 		return nil, fmt.Errorf("error parsing base URL: %w", err)
@@ -43,7 +179,12 @@ func (s *TemperatureService) GetTemperature(location string) (*TemperatureRespon
 	q.Set("location", location)
 	u.RawQuery = q.Encode()
 
-	resp, err := s.HTTPClient.Get(u.String())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error building temperature request: %w", err)
+	}
+
+	resp, err := s.HTTPClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("error calling temperature API: %w", err)
 	}
@@ -60,3 +201,62 @@ func (s *TemperatureService) GetTemperature(location string) (*TemperatureRespon
 
 	return &temperatureResp, nil
 }
+
+// runBackgroundRefresh periodically re-fetches every cached location so hot
+// locations stay warm and requests keep hitting the cache instead of racing
+// the upstream on expiry.
+func (s *TemperatureService) runBackgroundRefresh() {
+	ticker := time.NewTicker(s.refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.mu.RLock()
+			locations := make([]string, 0, len(s.cache))
+			for loc := range s.cache {
+				locations = append(locations, loc)
+			}
+			s.mu.RUnlock()
+
+			for _, loc := range locations {
+				if !s.breaker.Allow() {
+					continue
+				}
+				ctx, cancel := context.WithTimeout(context.Background(), s.HTTPClient.Timeout)
+				resp, err := s.fetch(ctx, loc)
+				cancel()
+				if err != nil {
+					s.breaker.RecordFailure()
+					continue
+				}
+				s.breaker.RecordSuccess()
+				s.store(loc, *resp)
+			}
+		}
+	}
+}
+
+// temperatureDriver adapts TemperatureService to the SensorDriver interface
+// so the temperature sensor type goes through the same DriverRegistry lookup
+// as every other sensor type.
+type temperatureDriver struct {
+	svc *TemperatureService
+}
+
+// NewTemperatureDriver wraps svc as a SensorDriver for registration.
+func NewTemperatureDriver(svc *TemperatureService) SensorDriver {
+	return &temperatureDriver{svc: svc}
+}
+
+func (d *temperatureDriver) Kind() models.SensorType { return models.Temperature }
+
+func (d *temperatureDriver) Fetch(ctx context.Context, sensor models.Sensor) (Reading, error) {
+	resp, err := d.svc.GetTemperature(ctx, sensor.Location)
+	if err != nil {
+		return Reading{}, err
+	}
+	return Reading{Value: resp.Value, Unit: resp.Unit, Timestamp: resp.Timestamp}, nil
+}
@@ -0,0 +1,56 @@
+// Package telemetry centralizes Prometheus metrics and OpenTelemetry tracing
+// setup so handlers and db don't each wire up their own.
+package telemetry
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	requestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "smarthome_http_requests_total",
+		Help: "Total HTTP requests, labeled by method, route and status.",
+	}, []string{"method", "route", "status"})
+
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "smarthome_http_request_duration_seconds",
+		Help:    "HTTP request latency, labeled by method and route.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	requestsInFlight = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "smarthome_http_requests_in_flight",
+		Help: "HTTP requests currently being served, labeled by method and route.",
+	}, []string{"method", "route"})
+)
+
+func init() {
+	prometheus.MustRegister(requestsTotal, requestDuration, requestsInFlight)
+}
+
+// GinMiddleware records per-route request counts, latency histograms, and
+// in-flight gauges, all labeled by method and route; request counts are
+// additionally labeled by response status.
+func GinMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		method := c.Request.Method
+
+		requestsInFlight.WithLabelValues(method, route).Inc()
+		defer requestsInFlight.WithLabelValues(method, route).Dec()
+
+		start := time.Now()
+		c.Next()
+
+		status := strconv.Itoa(c.Writer.Status())
+		requestsTotal.WithLabelValues(method, route, status).Inc()
+		requestDuration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,65 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ServiceName identifies this service in exported spans.
+const ServiceName = "smart_home"
+
+// Tracer is the package-wide tracer used across handlers, db and services so
+// a single trace covers HTTP -> DB -> upstream temperature-api.
+var Tracer trace.Tracer = otel.Tracer(ServiceName)
+
+// InitTracing configures a global TracerProvider exporting spans via OTLP,
+// reading the endpoint and other exporter settings from the standard
+// OTEL_EXPORTER_OTLP_* environment variables. If OTEL_EXPORTER_OTLP_ENDPOINT
+// is unset, tracing is left as a no-op. The returned func flushes and closes
+// the exporter and should be deferred.
+func InitTracing(ctx context.Context) (func(context.Context) error, error) {
+	if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracegrpc.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error creating otlp exporter: %w", err)
+	}
+
+	res, err := resource.New(ctx, resource.WithAttributes(semconv.ServiceName(ServiceName)))
+	if err != nil {
+		return nil, fmt.Errorf("error building otel resource: %w", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(tp)
+	Tracer = tp.Tracer(ServiceName)
+
+	return tp.Shutdown, nil
+}
+
+// EndSpan records err on span, if any, before ending it. It's meant to be
+// deferred right after a span is started:
+//
+//	ctx, span := telemetry.Tracer.Start(ctx, "db.GetSensors")
+//	defer func() { telemetry.EndSpan(span, err) }()
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}